@@ -0,0 +1,25 @@
+package avoca
+
+import "context"
+
+// attemptContextKey is the context key used to carry the attempt number
+// populated by a Retrier.
+type attemptContextKey struct{}
+
+// WithAttempt returns a copy of ctx carrying the given attempt number (1 for
+// the first attempt). Retrier implementations should call this before
+// invoking their retry callback, so that middleware composed via
+// WithMiddleware can tell which attempt produced the request it observes.
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptContextKey{}, attempt)
+}
+
+// AttemptFromContext returns the attempt number stored in ctx by
+// WithAttempt, or 1 if ctx carries none (i.e. the first or only attempt).
+func AttemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptContextKey{}).(int); ok {
+		return attempt
+	}
+
+	return 1
+}
@@ -0,0 +1,17 @@
+package avoca
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptFromContextDefault(t *testing.T) {
+	assert.Equal(t, 1, AttemptFromContext(context.Background()))
+}
+
+func TestWithAttempt(t *testing.T) {
+	ctx := WithAttempt(context.Background(), 3)
+	assert.Equal(t, 3, AttemptFromContext(ctx))
+}
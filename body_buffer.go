@@ -0,0 +1,59 @@
+package avoca
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// defaultMaxResponseBodyBuffer is the default value of
+// Client.maxResponseBodyBuffer: no limit.
+const defaultMaxResponseBodyBuffer int64 = 0
+
+// TruncatedBodyHeader is set on a response whose body was truncated because
+// it exceeded the limit configured via WithMaxResponseBodyBuffer.
+const TruncatedBodyHeader = "X-Avoca-Truncated-Body"
+
+// drainAndRebuffer reads res.Body (up to maxBuffer bytes, or without limit
+// when maxBuffer <= 0), closes the underlying body so the connection can be
+// reused by the transport, and replaces res.Body with an io.NopCloser over
+// the buffered bytes so it can be read again by the caller. If the body is
+// larger than maxBuffer, the remainder is discarded and res is marked with
+// TruncatedBodyHeader.
+func drainAndRebuffer(res *http.Response, maxBuffer int64) error {
+	if res == nil || res.Body == nil {
+		return nil
+	}
+	defer res.Body.Close()
+
+	if maxBuffer <= 0 {
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+
+		res.Body = io.NopCloser(bytes.NewReader(buf))
+
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, res.Body, maxBuffer); err != nil && err != io.EOF {
+		return err
+	}
+
+	discarded, err := io.Copy(io.Discard, res.Body)
+	if err != nil {
+		return err
+	}
+	if discarded > 0 {
+		if res.Header == nil {
+			res.Header = http.Header{}
+		}
+		res.Header.Set(TruncatedBodyHeader, "true")
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+
+	return nil
+}
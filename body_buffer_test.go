@@ -0,0 +1,77 @@
+package avoca
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainAndRebufferNilResponse(t *testing.T) {
+	assert.NoError(t, drainAndRebuffer(nil, 0))
+}
+
+func TestDrainAndRebufferNilBody(t *testing.T) {
+	res := &http.Response{}
+	assert.NoError(t, drainAndRebuffer(res, 0))
+}
+
+func TestDrainAndRebufferNoLimit(t *testing.T) {
+	res := &http.Response{Body: io.NopCloser(strings.NewReader(dummyResponseBody))}
+
+	require.NoError(t, drainAndRebuffer(res, 0))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, dummyResponseBody, string(body))
+	assert.Empty(t, res.Header.Get(TruncatedBodyHeader))
+}
+
+func TestDrainAndRebufferTruncates(t *testing.T) {
+	res := &http.Response{Body: io.NopCloser(strings.NewReader(dummyResponseBody))}
+
+	require.NoError(t, drainAndRebuffer(res, 3))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, dummyResponseBody[:3], string(body))
+	assert.Equal(t, "true", res.Header.Get(TruncatedBodyHeader))
+}
+
+func TestDrainAndRebufferUnderLimit(t *testing.T) {
+	res := &http.Response{Body: io.NopCloser(strings.NewReader(dummyResponseBody))}
+
+	require.NoError(t, drainAndRebuffer(res, int64(len(dummyResponseBody)+10)))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, dummyResponseBody, string(body))
+	assert.Empty(t, res.Header.Get(TruncatedBodyHeader))
+}
+
+func TestClientDoRebuffersFinalResponseBody(t *testing.T) {
+	internalClient := newMockHTTPClient(t, 0, 3)
+	client := NewClient(
+		WithHTTPClient(internalClient),
+		WithRetrier(&mockRetrier{maxAttempts: 1}),
+		WithRetryPolicy(func(statusCode int) bool {
+			return statusCode >= http.StatusInternalServerError
+		}),
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, dummyURL, nil)
+	require.NoError(t, err)
+	req.Header = dummyHeader
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, dummyResponseBody, string(body))
+}
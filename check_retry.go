@@ -0,0 +1,75 @@
+package avoca
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// CheckRetry decides, given the context, the response and the error of the
+// last attempt, whether the request should be retried. It mirrors the
+// convention used by hashicorp/go-retryablehttp: a non-nil error takes
+// precedence over the boolean and is returned as-is (e.g. to surface a
+// non-retryable error or a context error), while a nil error and a true
+// boolean mean the request is retryable.
+type CheckRetry func(ctx context.Context, resp *http.Response, err error) (bool, error)
+
+// DefaultCheckRetry retries on 5xx and 429 responses, and on transport
+// errors, except for context cancellation/deadline errors and TLS
+// certificate errors, which are treated as non-retryable.
+func DefaultCheckRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false, err
+		}
+		if isTLSCertError(err) {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	if resp != nil && (resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// isTLSCertError reports whether err is a certificate validation error, for
+// which retrying is pointless as it will keep failing the same way.
+func isTLSCertError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameError    x509.HostnameError
+		certInvalid      x509.CertificateInvalidError
+		certVerification *tls.CertificateVerificationError
+	)
+
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostnameError) ||
+		errors.As(err, &certInvalid) ||
+		errors.As(err, &certVerification)
+}
+
+// retryPolicyToCheckRetry adapts a legacy RetryPolicy to a CheckRetry,
+// preserving its original semantics: only the response status code is
+// consulted, and transport errors are never retried.
+func retryPolicyToCheckRetry(policy RetryPolicy) CheckRetry {
+	return func(_ context.Context, resp *http.Response, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		if resp == nil {
+			return false, nil
+		}
+
+		return policy(resp.StatusCode), nil
+	}
+}
@@ -0,0 +1,109 @@
+package avoca
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCheckRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		resp        *http.Response
+		err         error
+		expectRetry bool
+		expectErr   error
+	}{
+		{
+			name:        "5xx response",
+			ctx:         context.Background(),
+			resp:        &http.Response{StatusCode: http.StatusBadGateway},
+			expectRetry: true,
+		},
+		{
+			name:        "429 response",
+			ctx:         context.Background(),
+			resp:        &http.Response{StatusCode: http.StatusTooManyRequests},
+			expectRetry: true,
+		},
+		{
+			name:        "200 response",
+			ctx:         context.Background(),
+			resp:        &http.Response{StatusCode: http.StatusOK},
+			expectRetry: false,
+		},
+		{
+			name:        "transport error",
+			ctx:         context.Background(),
+			err:         errors.New("connection reset by peer"),
+			expectRetry: true,
+		},
+		{
+			name:      "context canceled error",
+			ctx:       context.Background(),
+			err:       context.Canceled,
+			expectErr: context.Canceled,
+		},
+		{
+			name:      "TLS certificate error",
+			ctx:       context.Background(),
+			err:       x509.UnknownAuthorityError{},
+			expectErr: x509.UnknownAuthorityError{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			retry, err := DefaultCheckRetry(tc.ctx, tc.resp, tc.err)
+
+			assert.Equal(t, tc.expectRetry, retry)
+			if tc.expectErr != nil {
+				assert.Equal(t, tc.expectErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDefaultCheckRetryCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	retry, err := DefaultCheckRetry(ctx, nil, nil)
+
+	assert.False(t, retry)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestIsTLSCertError(t *testing.T) {
+	assert.True(t, isTLSCertError(x509.UnknownAuthorityError{}))
+	assert.True(t, isTLSCertError(x509.HostnameError{}))
+	assert.True(t, isTLSCertError(&tls.CertificateVerificationError{}))
+	assert.False(t, isTLSCertError(errors.New("some other error")))
+}
+
+func TestRetryPolicyToCheckRetry(t *testing.T) {
+	checkRetry := retryPolicyToCheckRetry(func(statusCode int) bool {
+		return statusCode >= http.StatusInternalServerError
+	})
+
+	retry, err := checkRetry(context.Background(), &http.Response{StatusCode: http.StatusInternalServerError}, nil)
+	assert.True(t, retry)
+	assert.NoError(t, err)
+
+	retry, err = checkRetry(context.Background(), &http.Response{StatusCode: http.StatusOK}, nil)
+	assert.False(t, retry)
+	assert.NoError(t, err)
+
+	retry, err = checkRetry(context.Background(), nil, errFailRequest)
+	assert.False(t, retry)
+	assert.ErrorIs(t, err, errFailRequest)
+}
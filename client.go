@@ -13,11 +13,16 @@ import (
 // By default, the client uses:
 //     * a http.Client with a timeout set to 60 * time.Second
 //     * a retrier that does not retry
-//     * a retry policy that return false for all HTTP codes
+//     * DefaultCheckRetry to decide whether an attempt is retryable
+//     * no limit on the buffered response body size
+//     * Always, i.e. it retries regardless of the request method
 type Client struct {
-	client      Doer
-	retrier     Retrier
-	retryPolicy RetryPolicy
+	client                Doer
+	retrier               Retrier
+	checkRetry            CheckRetry
+	maxResponseBodyBuffer int64
+	idempotencyMode       IdempotencyMode
+	idempotencyKeyFunc    func() string
 }
 
 // Doer interface that match the standard HTTP client `http.Do` interface.
@@ -36,30 +41,80 @@ type RetryPolicy func(statusCode int) bool
 
 // Do makes an HTTP request with the native `http.Do` interface.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	var (
-		res *http.Response
-		err error
-	)
+	// Clone the header before anything (idempotency key, middleware) mutates
+	// it: callers commonly build one http.Header and reuse it across several
+	// Get/Post/... calls, and writing into that shared map would leak state
+	// (e.g. an Idempotency-Key) between logically-distinct requests and race
+	// under concurrent use.
+	req.Header = req.Header.Clone()
+
 	// Consume the body an prepare a reader
 	body, err := copyHTTPRequestBody(req)
 	if err != nil {
 		return nil, err
 	}
-	// Retry the calls
-	err = c.retrier.Do(req.Context(), func(context.Context) error {
-		// Overwrite the request body using a NopCloser
+
+	applyIdempotencyKey(c.idempotencyMode, c.idempotencyKeyFunc, req)
+
+	attempt := func(ctx context.Context) (*http.Response, error) {
+		// Overwrite the request body using a NopCloser, and propagate the
+		// (possibly attempt-tagged) context so middleware can observe it.
+		req = req.WithContext(ctx)
 		req.Body = newNopCloserFromBody(body)
 
-		res, err = c.client.Do(req)
-		if err != nil {
-			return err
+		res, doErr := c.client.Do(req)
+		if doErr == nil {
+			// Drain and rebuffer the body now, both so the connection can be
+			// reused by the transport on a retry, and so the response is
+			// still readable by the caller if this turns out to be the last
+			// attempt.
+			if bufErr := drainAndRebuffer(res, c.maxResponseBodyBuffer); bufErr != nil {
+				return res, bufErr
+			}
 		}
-		if c.retryPolicy(res.StatusCode) {
-			// Return a errStatus to try again
-			return ErrStatusCode
+
+		retry, checkErr := c.checkRetry(ctx, res, doErr)
+		if checkErr != nil {
+			return res, checkErr
 		}
-		// The request went fine, no need to retry
-		return nil
+		if retry && !isRetryAllowed(c.idempotencyMode, req.Method) {
+			// The gate only suppresses further attempts: the one that just
+			// ran always completes and its result is returned as final.
+			retry = false
+		}
+		if retry {
+			if doErr != nil {
+				// Keep the original transport error so that, once retries
+				// are exhausted, the caller sees it instead of a silent
+				// success (ErrStatusCode is reserved for retryable statuses).
+				return res, doErr
+			}
+			// Return ErrStatusCode to try again.
+			return res, ErrStatusCode
+		}
+
+		// The request went fine, no need to retry.
+		return res, doErr
+	}
+
+	// If the retrier can inspect the response (e.g. to honor a Retry-After
+	// header), let it drive the attempts directly.
+	if rar, ok := c.retrier.(ResponseAwareRetrier); ok {
+		res, err := rar.DoWithResponse(req.Context(), attempt)
+		if err != nil && !errors.Is(err, ErrStatusCode) {
+			return nil, err
+		}
+
+		return res, nil
+	}
+
+	// Otherwise, fall back to the plain Retrier interface.
+	var res *http.Response
+	err = c.retrier.Do(req.Context(), func(ctx context.Context) error {
+		var attemptErr error
+		res, attemptErr = attempt(ctx)
+
+		return attemptErr
 	})
 	if err != nil && !errors.Is(err, ErrStatusCode) {
 		return nil, err
@@ -168,10 +223,69 @@ func WithRetrier(retrier Retrier) Option {
 	}
 }
 
+// Middleware wraps a Doer to observe or mutate requests and responses
+// without replacing the underlying HTTP client, e.g. for logging, tracing,
+// or auth. Middlewares run once per attempt: the attempt number can be read
+// from the request's context with AttemptFromContext.
+type Middleware func(next Doer) Doer
+
+// WithMiddleware composes mw around the client's Doer: mw[0] is the
+// outermost layer, so it sees the request first and the response last. It
+// must be applied after WithHTTPClient, if any, so that it wraps the
+// intended Doer.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		for i := len(mw) - 1; i >= 0; i-- {
+			c.client = mw[i](c.client)
+		}
+	}
+}
+
 // WithRetryPolicy sets the retry policy.
+//
+// Deprecated: prefer WithCheckRetry, which also sees the error and the
+// response of each attempt. WithRetryPolicy is kept for backward
+// compatibility and is adapted into a CheckRetry under the hood.
 func WithRetryPolicy(retryPolicy RetryPolicy) Option {
 	return func(c *Client) {
-		c.retryPolicy = retryPolicy
+		c.checkRetry = retryPolicyToCheckRetry(retryPolicy)
+	}
+}
+
+// WithCheckRetry sets the function used to decide whether an attempt should
+// be retried, based on the context, the response and the error it produced.
+func WithCheckRetry(checkRetry CheckRetry) Option {
+	return func(c *Client) {
+		c.checkRetry = checkRetry
+	}
+}
+
+// WithMaxResponseBodyBuffer caps the number of response body bytes buffered
+// for re-reading by the caller to n. Bodies larger than n are truncated and
+// the response is marked with TruncatedBodyHeader. A value <= 0 means no
+// limit, which is the default.
+func WithMaxResponseBodyBuffer(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBodyBuffer = n
+	}
+}
+
+// WithIdempotency sets which requests may be retried. It defaults to
+// Always, preserving the client's historical behavior of retrying any
+// method.
+func WithIdempotency(mode IdempotencyMode) Option {
+	return func(c *Client) {
+		c.idempotencyMode = mode
+	}
+}
+
+// WithIdempotencyKeyFunc sets the function used to generate the
+// Idempotency-Key header when the client is configured with the
+// IdempotencyKey mode. It defaults to generating a random UUIDv4; pass this
+// option to plug in ULIDs or another generator.
+func WithIdempotencyKeyFunc(f func() string) Option {
+	return func(c *Client) {
+		c.idempotencyKeyFunc = f
 	}
 }
 
@@ -181,8 +295,11 @@ func NewClient(opts ...Option) *Client {
 		client: &http.Client{
 			Timeout: defaultHTTPTimeout,
 		},
-		retrier:     &noRetry{},
-		retryPolicy: defaultRetryPolicy,
+		retrier:               &noRetry{},
+		checkRetry:            DefaultCheckRetry,
+		maxResponseBodyBuffer: defaultMaxResponseBodyBuffer,
+		idempotencyMode:       Always,
+		idempotencyKeyFunc:    newIdempotencyKey,
 	}
 	for _, opt := range opts {
 		opt(&client)
@@ -196,9 +313,5 @@ const defaultHTTPTimeout = 60 * time.Second
 type noRetry struct{}
 
 func (r *noRetry) Do(ctx context.Context, fn func(context.Context) error) error {
-	return fn(ctx)
-}
-
-func defaultRetryPolicy(statusCode int) bool {
-	return false
+	return fn(WithAttempt(ctx, 1))
 }
@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"io"
-	"math/rand"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -368,7 +368,170 @@ func TestNewNopCloserFromBodyNil(t *testing.T) {
 	assert.Nil(t, newNopCloserFromBody(nil))
 }
 
-func TestDefaultRetryPolicy(t *testing.T) {
-	n := rand.Int() // nolint:gosec
-	assert.False(t, defaultRetryPolicy(n))
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithMiddlewareOrder(t *testing.T) {
+	var trace []string
+
+	record := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return doerFunc(func(req *http.Request) (*http.Response, error) {
+				trace = append(trace, name, req.URL.String())
+
+				return next.Do(req)
+			})
+		}
+	}
+
+	client := NewClient(
+		WithHTTPClient(newMockHTTPClient(t, 0, 0)),
+		WithMiddleware(record("outer"), record("inner")),
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, dummyURL, nil)
+	require.NoError(t, err)
+	req.Header = dummyHeader
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, []string{"outer", dummyURL, "inner", dummyURL}, trace)
+}
+
+func TestClientDoIdempotentMethodsOnlyStopsRetryingPost(t *testing.T) {
+	internalClient := newMockHTTPClient(t, 0, 3)
+	client := NewClient(
+		WithHTTPClient(internalClient),
+		WithRetrier(&mockRetrier{maxAttempts: 5}),
+		WithRetryPolicy(func(statusCode int) bool {
+			return statusCode >= http.StatusInternalServerError
+		}),
+		WithIdempotency(IdempotentMethodsOnly),
+	)
+
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodPost, dummyURL, strings.NewReader(dummyRequestBody),
+	)
+	require.NoError(t, err)
+	req.Header = dummyHeader
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, 1, internalClient.count)
+}
+
+func TestClientDoIdempotencyKeySetsHeaderAndRetriesPost(t *testing.T) {
+	var (
+		calls    int
+		seenKeys []string
+	)
+
+	client := NewClient(
+		WithHTTPClient(doerFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			seenKeys = append(seenKeys, req.Header.Get("Idempotency-Key"))
+			if calls < 3 {
+				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(strings.NewReader(dummyResponseBody)),
+				}, nil
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(dummyResponseBody)),
+			}, nil
+		})),
+		WithRetrier(&mockRetrier{maxAttempts: 5}),
+		WithRetryPolicy(func(statusCode int) bool {
+			return statusCode >= http.StatusInternalServerError
+		}),
+		WithIdempotency(IdempotencyKey),
+	)
+
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodPost, dummyURL, strings.NewReader(dummyRequestBody),
+	)
+	require.NoError(t, err)
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, calls)
+	assert.NotEmpty(t, seenKeys[0])
+	assert.Equal(t, seenKeys[0], seenKeys[1])
+	assert.Equal(t, seenKeys[0], seenKeys[2])
+}
+
+func TestClientDoClonesSharedHeaderAcrossCalls(t *testing.T) {
+	var seenKeys []string
+
+	client := NewClient(
+		WithHTTPClient(doerFunc(func(req *http.Request) (*http.Response, error) {
+			seenKeys = append(seenKeys, req.Header.Get("Idempotency-Key"))
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(dummyResponseBody)),
+			}, nil
+		})),
+		WithIdempotency(IdempotencyKey),
+	)
+
+	sharedHeaders := http.Header{"content-type": []string{"application/json"}}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Post(context.Background(), dummyURL, strings.NewReader(dummyRequestBody), sharedHeaders)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	require.Len(t, seenKeys, 2)
+	assert.NotEmpty(t, seenKeys[0])
+	assert.NotEmpty(t, seenKeys[1])
+	assert.NotEqual(t, seenKeys[0], seenKeys[1])
+	assert.Empty(t, sharedHeaders.Get("Idempotency-Key"))
+}
+
+func TestWithMiddlewareSeesAttemptNumber(t *testing.T) {
+	var attempts []int
+
+	record := func(next Doer) Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			attempts = append(attempts, AttemptFromContext(req.Context()))
+
+			return next.Do(req)
+		})
+	}
+
+	hardFailures := 2
+	client := NewClient(
+		WithHTTPClient(newMockHTTPClient(t, hardFailures, hardFailures)),
+		WithMiddleware(record),
+		WithRetrier(&ExponentialBackoffRetrier{
+			Base:        time.Millisecond,
+			Ceiling:     2 * time.Millisecond,
+			MaxAttempts: hardFailures + 1,
+		}),
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, dummyURL, nil)
+	require.NoError(t, err)
+	req.Header = dummyHeader
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, []int{1, 2, 3}, attempts)
 }
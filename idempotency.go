@@ -0,0 +1,76 @@
+package avoca
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader is the header set on POST/PATCH requests when the
+// client is configured with the IdempotencyKey mode.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyMode controls which attempts Client.Do is allowed to retry.
+type IdempotencyMode int
+
+const (
+	// Always retries regardless of the request method. This is the default,
+	// preserving the client's historical behavior.
+	Always IdempotencyMode = iota
+	// IdempotentMethodsOnly only retries idempotent methods (GET, HEAD, PUT,
+	// DELETE, OPTIONS); POST and PATCH requests are only attempted once.
+	IdempotentMethodsOnly
+	// IdempotencyKey allows retrying POST and PATCH requests by
+	// auto-generating an Idempotency-Key header when the caller hasn't set
+	// one, so the server can safely deduplicate partially-processed
+	// attempts.
+	IdempotencyKey
+)
+
+// isIdempotentMethod reports whether method is considered idempotent.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryAllowed reports whether a request for method may be retried under mode.
+func isRetryAllowed(mode IdempotencyMode, method string) bool {
+	switch mode {
+	case IdempotentMethodsOnly:
+		return isIdempotentMethod(method)
+	case IdempotencyKey, Always:
+		return true
+	default:
+		return true
+	}
+}
+
+// applyIdempotencyKey sets the Idempotency-Key header on req if mode is
+// IdempotencyKey, the method is POST or PATCH, and the header isn't already
+// set by the caller.
+func applyIdempotencyKey(mode IdempotencyMode, keyFunc func() string, req *http.Request) {
+	if mode != IdempotencyKey {
+		return
+	}
+	if req.Method != http.MethodPost && req.Method != http.MethodPatch {
+		return
+	}
+	if req.Header.Get(idempotencyKeyHeader) != "" {
+		return
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	req.Header.Set(idempotencyKeyHeader, keyFunc())
+}
+
+// newIdempotencyKey is the default idempotency key generator (see
+// WithIdempotencyKeyFunc): a random UUIDv4.
+func newIdempotencyKey() string {
+	return uuid.NewString()
+}
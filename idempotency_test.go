@@ -0,0 +1,60 @@
+package avoca
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsIdempotentMethod(t *testing.T) {
+	assert.True(t, isIdempotentMethod(http.MethodGet))
+	assert.True(t, isIdempotentMethod(http.MethodHead))
+	assert.True(t, isIdempotentMethod(http.MethodPut))
+	assert.True(t, isIdempotentMethod(http.MethodDelete))
+	assert.True(t, isIdempotentMethod(http.MethodOptions))
+	assert.False(t, isIdempotentMethod(http.MethodPost))
+	assert.False(t, isIdempotentMethod(http.MethodPatch))
+}
+
+func TestIsRetryAllowed(t *testing.T) {
+	assert.True(t, isRetryAllowed(Always, http.MethodPost))
+	assert.True(t, isRetryAllowed(IdempotentMethodsOnly, http.MethodGet))
+	assert.False(t, isRetryAllowed(IdempotentMethodsOnly, http.MethodPost))
+	assert.True(t, isRetryAllowed(IdempotencyKey, http.MethodPost))
+}
+
+func TestApplyIdempotencyKeySetsHeader(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, dummyURL, nil)
+	assert.NoError(t, err)
+
+	applyIdempotencyKey(IdempotencyKey, func() string { return "fixed-key" }, req)
+	assert.Equal(t, "fixed-key", req.Header.Get(idempotencyKeyHeader))
+}
+
+func TestApplyIdempotencyKeyPreservesExisting(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, dummyURL, nil)
+	assert.NoError(t, err)
+	req.Header.Set(idempotencyKeyHeader, "caller-key")
+
+	applyIdempotencyKey(IdempotencyKey, func() string { return "fixed-key" }, req)
+	assert.Equal(t, "caller-key", req.Header.Get(idempotencyKeyHeader))
+}
+
+func TestApplyIdempotencyKeySkippedForOtherModesAndMethods(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, dummyURL, nil)
+	assert.NoError(t, err)
+
+	applyIdempotencyKey(Always, func() string { return "fixed-key" }, req)
+	assert.Empty(t, req.Header.Get(idempotencyKeyHeader))
+
+	getReq, err := http.NewRequest(http.MethodGet, dummyURL, nil)
+	assert.NoError(t, err)
+
+	applyIdempotencyKey(IdempotencyKey, func() string { return "fixed-key" }, getReq)
+	assert.Empty(t, getReq.Header.Get(idempotencyKeyHeader))
+}
+
+func TestNewIdempotencyKey(t *testing.T) {
+	assert.NotEqual(t, newIdempotencyKey(), newIdempotencyKey())
+}
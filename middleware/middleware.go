@@ -0,0 +1,120 @@
+// Package middleware provides a small set of built-in avoca.Middleware
+// implementations for common cross-cutting concerns: logging, request IDs,
+// bearer token authentication and OpenTelemetry tracing.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vthiery/avoca"
+)
+
+// Logger returns a Middleware that logs the method, URL, status code,
+// duration and attempt number of every attempt, using l (e.g. a standard
+// library *log.Logger).
+func Logger(l interface {
+	Printf(format string, args ...interface{})
+}) avoca.Middleware {
+	return func(next avoca.Doer) avoca.Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.Do(req)
+
+			status := 0
+			if res != nil {
+				status = res.StatusCode
+			}
+
+			l.Printf(
+				"method=%s url=%s status=%d duration=%s attempt=%d err=%v",
+				req.Method, req.URL.String(), status, time.Since(start), avoca.AttemptFromContext(req.Context()), err,
+			)
+
+			return res, err
+		})
+	}
+}
+
+// RequestID returns a Middleware that sets an X-Request-ID header on the
+// request if it is not already present.
+func RequestID() avoca.Middleware {
+	return func(next avoca.Doer) avoca.Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Request-ID") == "" {
+				// Clone before writing: req.Header may be a map the caller
+				// reuses across requests, and mutating it in place would leak
+				// this request's ID into later ones and race concurrently.
+				req.Header = req.Header.Clone()
+				req.Header.Set("X-Request-ID", uuid.NewString())
+			}
+
+			return next.Do(req)
+		})
+	}
+}
+
+// BearerToken returns a Middleware that sets the Authorization header to
+// "Bearer <token>" for every attempt, using the token returned by src.
+func BearerToken(src func(context.Context) (string, error)) avoca.Middleware {
+	return func(next avoca.Doer) avoca.Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := src(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			// Clone before writing: req.Header may be a map the caller
+			// reuses across requests, and mutating it in place would leak
+			// this token into later, unrelated requests and race
+			// concurrently.
+			req.Header = req.Header.Clone()
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			return next.Do(req)
+		})
+	}
+}
+
+// OpenTelemetry returns a Middleware that starts a client span per attempt
+// using tracer, annotated with http.method, http.status_code and
+// http.retry_count.
+func OpenTelemetry(tracer trace.Tracer) avoca.Middleware {
+	return func(next avoca.Doer) avoca.Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(
+				req.Context(), req.Method+" "+req.URL.Path, trace.WithSpanKind(trace.SpanKindClient),
+			)
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			span.SetAttributes(
+				attribute.String("http.method", req.Method),
+				attribute.Int("http.retry_count", avoca.AttemptFromContext(ctx)-1),
+			)
+
+			res, err := next.Do(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+
+				return res, err
+			}
+			span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+
+			return res, nil
+		})
+	}
+}
+
+// doerFunc adapts a function to the avoca.Doer interface.
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
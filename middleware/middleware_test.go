@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vthiery/avoca"
+)
+
+const dummyURL = "https://example.com/resource"
+
+type mockDoer struct {
+	res *http.Response
+	err error
+}
+
+func (d *mockDoer) Do(req *http.Request) (*http.Response, error) {
+	return d.res, d.err
+}
+
+type mockLogger struct {
+	lines []string
+}
+
+func (l *mockLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, format)
+}
+
+func newRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, dummyURL, nil)
+	require.NoError(t, err)
+
+	return req
+}
+
+func TestLogger(t *testing.T) {
+	l := &mockLogger{}
+	doer := Logger(l)(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	_, err := doer.Do(newRequest(t))
+	require.NoError(t, err)
+	assert.Len(t, l.lines, 1)
+}
+
+func TestRequestIDSetsHeaderIfAbsent(t *testing.T) {
+	var gotHeader string
+	doer := RequestID()(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	req := newRequest(t)
+	_, err := doer.Do(req)
+	require.NoError(t, err)
+
+	gotHeader = req.Header.Get("X-Request-ID")
+	assert.NotEmpty(t, gotHeader)
+}
+
+func TestRequestIDPreservesExistingHeader(t *testing.T) {
+	doer := RequestID()(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	req := newRequest(t)
+	req.Header.Set("X-Request-ID", "existing-id")
+
+	_, err := doer.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "existing-id", req.Header.Get("X-Request-ID"))
+}
+
+func TestRequestIDDoesNotMutateSharedHeader(t *testing.T) {
+	doer := RequestID()(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	sharedHeaders := http.Header{}
+
+	firstReq := newRequest(t)
+	firstReq.Header = sharedHeaders
+	_, err := doer.Do(firstReq)
+	require.NoError(t, err)
+
+	secondReq := newRequest(t)
+	secondReq.Header = sharedHeaders
+	_, err = doer.Do(secondReq)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, firstReq.Header.Get("X-Request-ID"), secondReq.Header.Get("X-Request-ID"))
+	assert.Empty(t, sharedHeaders.Get("X-Request-ID"))
+}
+
+func TestBearerToken(t *testing.T) {
+	doer := BearerToken(func(context.Context) (string, error) {
+		return "my-token", nil
+	})(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	req := newRequest(t)
+	_, err := doer.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer my-token", req.Header.Get("Authorization"))
+}
+
+func TestBearerTokenSourceError(t *testing.T) {
+	errSource := errors.New("cannot fetch token")
+	doer := BearerToken(func(context.Context) (string, error) {
+		return "", errSource
+	})(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	_, err := doer.Do(newRequest(t))
+	assert.ErrorIs(t, err, errSource)
+}
+
+func TestBearerTokenDoesNotMutateSharedHeader(t *testing.T) {
+	doer := BearerToken(func(context.Context) (string, error) {
+		return "secret-token", nil
+	})(&mockDoer{res: &http.Response{StatusCode: http.StatusOK}})
+
+	sharedHeaders := http.Header{}
+
+	firstReq := newRequest(t)
+	firstReq.Header = sharedHeaders
+	_, err := doer.Do(firstReq)
+	require.NoError(t, err)
+
+	secondReq := newRequest(t)
+	secondReq.Header = sharedHeaders
+
+	assert.Empty(t, secondReq.Header.Get("Authorization"))
+}
+
+func TestOpenTelemetrySuccess(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	tracer := tp.Tracer("avoca/middleware_test")
+
+	doer := OpenTelemetry(tracer)(&mockDoer{res: &http.Response{StatusCode: http.StatusCreated}})
+
+	req := avoca.WithAttempt(context.Background(), 2)
+	httpReq, err := http.NewRequestWithContext(req, http.MethodPost, dummyURL, nil)
+	require.NoError(t, err)
+
+	_, err = doer.Do(httpReq)
+	require.NoError(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+
+	var sawStatus, sawRetryCount bool
+	for _, attr := range spans[0].Attributes() {
+		switch string(attr.Key) {
+		case "http.status_code":
+			sawStatus = attr.Value.AsInt64() == http.StatusCreated
+		case "http.retry_count":
+			sawRetryCount = attr.Value.AsInt64() == 1
+		}
+	}
+	assert.True(t, sawStatus)
+	assert.True(t, sawRetryCount)
+	assert.True(t, strings.HasPrefix(spans[0].Name(), http.MethodPost))
+}
+
+func TestOpenTelemetryError(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	tracer := tp.Tracer("avoca/middleware_test")
+
+	errDo := errors.New("boom")
+	doer := OpenTelemetry(tracer)(&mockDoer{err: errDo})
+
+	_, err := doer.Do(newRequest(t))
+	assert.ErrorIs(t, err, errDo)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.NotEmpty(t, spans[0].Events())
+}
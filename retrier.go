@@ -0,0 +1,169 @@
+package avoca
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default configuration values used by ExponentialBackoffRetrier.
+const (
+	defaultBackoffBase        = 1 * time.Second
+	defaultBackoffCeiling     = 10 * time.Second
+	defaultBackoffJitter      = 1 * time.Second
+	defaultBackoffMaxAttempts = 5
+)
+
+// ResponseAwareRetrier is an optional extension of Retrier for
+// implementations that need to inspect the *http.Response (and error)
+// produced by an attempt, e.g. to honor a Retry-After header. Client.Do uses
+// DoWithResponse when the configured Retrier implements this interface, and
+// falls back to Do otherwise.
+type ResponseAwareRetrier interface {
+	Retrier
+
+	// DoWithResponse behaves like Retrier.Do, except that fn also returns
+	// the *http.Response produced by the attempt, which the retrier may
+	// inspect before the next attempt and returns once retries are exhausted.
+	DoWithResponse(ctx context.Context, fn func(context.Context) (*http.Response, error)) (*http.Response, error)
+}
+
+// ExponentialBackoffRetrier is a Retrier that waits an exponentially
+// increasing amount of time between attempts, following the algorithm used
+// by golang.org/x/crypto/acme: for the n-th failure (n starting at 1), it
+// sleeps min(2^(n-1) * Base, Ceiling) + random(Jitter) before the next
+// attempt.
+//
+// When the previous attempt produced a *http.Response with a Retry-After
+// header (delta-seconds or HTTP-date form), that value is used instead of
+// the computed backoff, provided it is positive.
+type ExponentialBackoffRetrier struct {
+	// Base is the initial backoff duration. Defaults to 1s.
+	Base time.Duration
+	// Ceiling caps the computed backoff, before jitter is added. Defaults to 10s.
+	Ceiling time.Duration
+	// Jitter is the upper bound of the extra random delay added to each backoff.
+	// NewExponentialBackoffRetrier sets it to 1s; a zero or negative value adds no jitter.
+	Jitter time.Duration
+	// MaxAttempts is the maximum number of attempts, including the first one. Defaults to 5.
+	MaxAttempts int
+}
+
+// NewExponentialBackoffRetrier returns an ExponentialBackoffRetrier configured
+// with a 1s base, a 10s ceiling, 1s of jitter and 5 attempts.
+func NewExponentialBackoffRetrier() *ExponentialBackoffRetrier {
+	return &ExponentialBackoffRetrier{
+		Base:        defaultBackoffBase,
+		Ceiling:     defaultBackoffCeiling,
+		Jitter:      defaultBackoffJitter,
+		MaxAttempts: defaultBackoffMaxAttempts,
+	}
+}
+
+// Do implements the Retrier interface.
+func (r *ExponentialBackoffRetrier) Do(ctx context.Context, fn func(context.Context) error) error {
+	_, err := r.DoWithResponse(ctx, func(ctx context.Context) (*http.Response, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
+
+// DoWithResponse implements the ResponseAwareRetrier interface.
+func (r *ExponentialBackoffRetrier) DoWithResponse(
+	ctx context.Context,
+	fn func(context.Context) (*http.Response, error),
+) (*http.Response, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	for attempt := 1; attempt <= r.maxAttempts(); attempt++ {
+		res, err = fn(WithAttempt(ctx, attempt))
+		if err == nil {
+			return res, nil
+		}
+		if attempt == r.maxAttempts() {
+			break
+		}
+
+		wait := retryAfter(res)
+		if wait <= 0 {
+			wait = r.backoff(attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return res, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return res, err
+}
+
+// backoff returns the delay to wait after the n-th failed attempt, including jitter.
+func (r *ExponentialBackoffRetrier) backoff(n int) time.Duration {
+	base, ceiling, jitter := r.Base, r.Ceiling, r.Jitter
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+	if ceiling <= 0 {
+		ceiling = defaultBackoffCeiling
+	}
+
+	d := base * time.Duration(int64(1)<<uint(n-1))
+	if d <= 0 || d > ceiling {
+		d = ceiling
+	}
+	if jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(jitter))) // nolint:gosec
+	}
+
+	return d
+}
+
+func (r *ExponentialBackoffRetrier) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return defaultBackoffMaxAttempts
+	}
+
+	return r.MaxAttempts
+}
+
+// retryAfter parses the Retry-After header off res, in either the
+// delta-seconds or the HTTP-date form, and returns the corresponding
+// duration to wait. It returns 0 if res is nil or the header is absent,
+// empty, invalid, or would yield a non-positive duration.
+func retryAfter(res *http.Response) time.Duration {
+	if res == nil {
+		return 0
+	}
+
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+
+		return time.Duration(secs) * time.Second
+	}
+
+	if date, err := http.ParseTime(v); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
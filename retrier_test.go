@@ -0,0 +1,160 @@
+package avoca
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nolint:gochecknoglobals
+var errRetrierAttempt = errors.New("attempt failed")
+
+func TestNewExponentialBackoffRetrier(t *testing.T) {
+	r := NewExponentialBackoffRetrier()
+
+	assert.Equal(t, defaultBackoffBase, r.Base)
+	assert.Equal(t, defaultBackoffCeiling, r.Ceiling)
+	assert.Equal(t, defaultBackoffJitter, r.Jitter)
+	assert.Equal(t, defaultBackoffMaxAttempts, r.MaxAttempts)
+}
+
+func TestExponentialBackoffRetrierDoWithResponseSuccess(t *testing.T) {
+	r := &ExponentialBackoffRetrier{
+		Base:        time.Millisecond,
+		Ceiling:     4 * time.Millisecond,
+		Jitter:      time.Millisecond,
+		MaxAttempts: 5,
+	}
+
+	attempts := 0
+	res, err := r.DoWithResponse(context.Background(), func(context.Context) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errRetrierAttempt
+		}
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestExponentialBackoffRetrierDoWithResponseExhausted(t *testing.T) {
+	r := &ExponentialBackoffRetrier{
+		Base:        time.Millisecond,
+		Ceiling:     2 * time.Millisecond,
+		Jitter:      time.Millisecond,
+		MaxAttempts: 3,
+	}
+
+	attempts := 0
+	res, err := r.DoWithResponse(context.Background(), func(context.Context) (*http.Response, error) {
+		attempts++
+
+		return nil, errRetrierAttempt
+	})
+
+	assert.ErrorIs(t, err, errRetrierAttempt)
+	assert.Nil(t, res)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestExponentialBackoffRetrierDoContextCancelled(t *testing.T) {
+	r := &ExponentialBackoffRetrier{
+		Base:        50 * time.Millisecond,
+		Ceiling:     time.Second,
+		MaxAttempts: 3,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := r.Do(ctx, func(context.Context) error {
+		return errRetrierAttempt
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExponentialBackoffRetrierHonorsRetryAfterDeltaSeconds(t *testing.T) {
+	r := &ExponentialBackoffRetrier{
+		Base:        time.Hour,
+		Ceiling:     time.Hour,
+		MaxAttempts: 2,
+	}
+
+	attempts := 0
+	start := time.Now()
+
+	_, err := r.DoWithResponse(context.Background(), func(context.Context) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			res := &http.Response{Header: http.Header{}}
+			res.Header.Set("Retry-After", "1")
+
+			return res, errRetrierAttempt
+		}
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Hour)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		res    *http.Response
+		expect time.Duration
+	}{
+		{
+			name:   "nil response",
+			res:    nil,
+			expect: 0,
+		},
+		{
+			name:   "no header",
+			res:    &http.Response{Header: http.Header{}},
+			expect: 0,
+		},
+		{
+			name: "delta seconds",
+			res: &http.Response{Header: http.Header{
+				"Retry-After": []string{"5"},
+			}},
+			expect: 5 * time.Second,
+		},
+		{
+			name: "invalid value",
+			res: &http.Response{Header: http.Header{
+				"Retry-After": []string{"not-a-date"},
+			}},
+			expect: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, retryAfter(tc.res))
+		})
+	}
+}
+
+func TestRetryAfterHTTPDate(t *testing.T) {
+	date := time.Now().Add(5 * time.Second).UTC()
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", date.Format(http.TimeFormat))
+
+	got := retryAfter(res)
+	assert.InDelta(t, 5*time.Second, got, float64(2*time.Second))
+}